@@ -0,0 +1,80 @@
+// Package attest verifies Nitro Enclave attestation documents returned
+// by the randsrv /attestation endpoint, so a caller can pin the
+// enclave's PPOPRF public key and active epoch tags to a known
+// measurement before trusting /randomness.
+package attest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	nitro "github.com/brave-experiments/nitro-enclave-utils"
+)
+
+// PCRAllowlist maps a PCR index to the measurement values that are
+// considered trustworthy for that PCR.
+type PCRAllowlist map[int][][]byte
+
+// Bound is the PPOPRF state a caller may pin after a successful
+// Verify.
+type Bound struct {
+	PublicKey string
+	Epochs    []uint8
+}
+
+// attestedMetadata mirrors the user_data payload randsrv embeds in its
+// attestation document.
+type attestedMetadata struct {
+	PublicKey string  `json:"publicKey"`
+	Epochs    []uint8 `json:"epochs"`
+}
+
+// Verify checks that raw is a COSE_Sign1 attestation document rooted
+// in the AWS Nitro certificate chain, in which every PCR named in
+// allowlist is present with one of its allowed values, and returns the
+// PPOPRF public key and epoch tags it embeds in user_data. PCRs that
+// allowlist does not name are not checked.
+func Verify(raw []byte, allowlist PCRAllowlist) (*Bound, error) {
+	doc, err := nitro.VerifyAttestationDocument(raw)
+	if err != nil {
+		return nil, fmt.Errorf("attest: invalid attestation document: %w", err)
+	}
+	return boundFromDocument(doc, allowlist)
+}
+
+// boundFromDocument checks doc's PCRs against allowlist and parses the
+// PPOPRF state out of its user_data. It is split out from Verify so the
+// PCR and parsing logic can be exercised without a real attestation
+// document.
+func boundFromDocument(doc *nitro.AttestationDocument, allowlist PCRAllowlist) (*Bound, error) {
+	if len(allowlist) == 0 {
+		return nil, fmt.Errorf("attest: PCR allowlist is empty")
+	}
+
+	for pcr, allowed := range allowlist {
+		measured, ok := doc.PCRs[pcr]
+		if !ok {
+			return nil, fmt.Errorf("attest: document does not cover PCR%d", pcr)
+		}
+		if !measurementAllowed(allowed, measured) {
+			return nil, fmt.Errorf("attest: PCR%d does not match the allowlist", pcr)
+		}
+	}
+
+	var meta attestedMetadata
+	if err := json.Unmarshal(doc.UserData, &meta); err != nil {
+		return nil, fmt.Errorf("attest: malformed user_data: %w", err)
+	}
+
+	return &Bound{PublicKey: meta.PublicKey, Epochs: meta.Epochs}, nil
+}
+
+func measurementAllowed(allowed [][]byte, measured []byte) bool {
+	for _, m := range allowed {
+		if bytes.Equal(m, measured) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package attest
+
+import (
+	"testing"
+
+	nitro "github.com/brave-experiments/nitro-enclave-utils"
+)
+
+func TestMeasurementAllowed(t *testing.T) {
+	allowed := [][]byte{{1, 2, 3}, {4, 5, 6}}
+
+	if !measurementAllowed(allowed, []byte{4, 5, 6}) {
+		t.Error("expected a listed measurement to be allowed")
+	}
+	if measurementAllowed(allowed, []byte{9, 9, 9}) {
+		t.Error("expected an unlisted measurement to be rejected")
+	}
+}
+
+func TestBoundFromDocumentRejectsEmptyAllowlist(t *testing.T) {
+	doc := &nitro.AttestationDocument{
+		PCRs:     map[int][]byte{0: {1, 2, 3}},
+		UserData: []byte(`{"publicKey":"ab","epochs":[0]}`),
+	}
+
+	if _, err := boundFromDocument(doc, PCRAllowlist{}); err == nil {
+		t.Fatal("expected an empty allowlist to be rejected")
+	}
+}
+
+func TestBoundFromDocumentRejectsPCRMismatch(t *testing.T) {
+	doc := &nitro.AttestationDocument{
+		PCRs:     map[int][]byte{0: {1, 2, 3}},
+		UserData: []byte(`{"publicKey":"ab","epochs":[0]}`),
+	}
+	allowlist := PCRAllowlist{0: {{9, 9, 9}}}
+
+	if _, err := boundFromDocument(doc, allowlist); err == nil {
+		t.Fatal("expected a PCR mismatch to be rejected")
+	}
+}
+
+func TestBoundFromDocumentRejectsMissingPCR(t *testing.T) {
+	doc := &nitro.AttestationDocument{
+		PCRs:     map[int][]byte{0: {1, 2, 3}},
+		UserData: []byte(`{"publicKey":"ab","epochs":[0]}`),
+	}
+	allowlist := PCRAllowlist{1: {{1, 2, 3}}}
+
+	if _, err := boundFromDocument(doc, allowlist); err == nil {
+		t.Fatal("expected a PCR absent from the document to be rejected")
+	}
+}
+
+func TestBoundFromDocumentParsesUserData(t *testing.T) {
+	doc := &nitro.AttestationDocument{
+		PCRs:     map[int][]byte{0: {1, 2, 3}},
+		UserData: []byte(`{"publicKey":"deadbeef","epochs":[2,3,4]}`),
+	}
+	allowlist := PCRAllowlist{0: {{1, 2, 3}}}
+
+	bound, err := boundFromDocument(doc, allowlist)
+	if err != nil {
+		t.Fatalf("boundFromDocument: %s", err)
+	}
+	if bound.PublicKey != "deadbeef" {
+		t.Errorf("PublicKey = %q, want %q", bound.PublicKey, "deadbeef")
+	}
+	if len(bound.Epochs) != 3 || bound.Epochs[0] != 2 || bound.Epochs[2] != 4 {
+		t.Errorf("Epochs = %v, want [2 3 4]", bound.Epochs)
+	}
+}
+
+func TestBoundFromDocumentRejectsMalformedUserData(t *testing.T) {
+	doc := &nitro.AttestationDocument{
+		PCRs:     map[int][]byte{0: {1, 2, 3}},
+		UserData: []byte(`not json`),
+	}
+	allowlist := PCRAllowlist{0: {{1, 2, 3}}}
+
+	if _, err := boundFromDocument(doc, allowlist); err == nil {
+		t.Fatal("expected malformed user_data to be rejected")
+	}
+}
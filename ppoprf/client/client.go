@@ -0,0 +1,78 @@
+// Package client lets callers outside the enclave validate ppoprf
+// randomness without trusting the transport that carried it.
+package client
+
+import (
+	"crypto/sha512"
+	"errors"
+
+	"github.com/bwesterb/go-ristretto"
+)
+
+// proofSize is the length in bytes of a DLEQ proof: a Chaum-Pedersen
+// (challenge, response) scalar pair.
+const proofSize = 64
+
+// Verify checks a DLEQ proof showing that output is the PPOPRF
+// evaluation of input under metadata tag md and the secret key
+// committed to by publicKey. input and output are Ristretto points in
+// their standard 32-byte encoding, and proof is the (challenge,
+// response) scalar pair returned alongside a verifiable evaluation.
+func Verify(input, output, proof, publicKey []byte, md uint8) (bool, error) {
+	if len(proof) != proofSize {
+		return false, errors.New("Verify: proof has the wrong length")
+	}
+
+	var m, z, y ristretto.Point
+	if err := m.UnmarshalBinary(input); err != nil {
+		return false, errors.New("Verify: invalid input point")
+	}
+	if err := z.UnmarshalBinary(output); err != nil {
+		return false, errors.New("Verify: invalid output point")
+	}
+	if err := y.UnmarshalBinary(publicKey); err != nil {
+		return false, errors.New("Verify: invalid public key")
+	}
+
+	var challenge, response ristretto.Scalar
+	if err := challenge.UnmarshalBinary(proof[:32]); err != nil {
+		return false, errors.New("Verify: invalid proof challenge")
+	}
+	if err := response.UnmarshalBinary(proof[32:]); err != nil {
+		return false, errors.New("Verify: invalid proof response")
+	}
+
+	// Recompute the two Chaum-Pedersen commitments:
+	//   a = response*G + challenge*Y
+	//   b = response*M + challenge*Z
+	var g ristretto.Point
+	g.SetBase()
+
+	var a, b, t1, t2 ristretto.Point
+	a.ScalarMult(&g, &response)
+	t1.ScalarMult(&y, &challenge)
+	a.Add(&a, &t1)
+
+	b.ScalarMult(&m, &response)
+	t2.ScalarMult(&z, &challenge)
+	b.Add(&b, &t2)
+
+	recomputed := deriveChallenge(&g, &y, &m, &z, &a, &b, md)
+	return recomputed.Equals(&challenge), nil
+}
+
+// deriveChallenge hashes the DLEQ transcript down to a scalar the same
+// way the server derives its Fiat-Shamir challenge.
+func deriveChallenge(g, y, m, z, a, b *ristretto.Point, md uint8) (c ristretto.Scalar) {
+	h := sha512.New()
+	for _, p := range []*ristretto.Point{g, y, m, z, a, b} {
+		enc, _ := p.MarshalBinary()
+		h.Write(enc)
+	}
+	h.Write([]byte{md})
+
+	var buf [64]byte
+	copy(buf[:], h.Sum(nil))
+	c.SetReduced(&buf)
+	return c
+}
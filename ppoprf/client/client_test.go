@@ -0,0 +1,114 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/bwesterb/go-ristretto"
+)
+
+// proveTestVector builds a DLEQ proof for a random key k and input
+// point m, using the same Chaum-Pedersen construction Verify checks.
+// It lets tests exercise Verify without a cgo-backed server.
+func proveTestVector(t *testing.T, md uint8) (input, output, proof, publicKey []byte) {
+	t.Helper()
+
+	var k ristretto.Scalar
+	k.Rand()
+
+	var g, y ristretto.Point
+	g.SetBase()
+	y.ScalarMult(&g, &k)
+
+	var m, z ristretto.Point
+	m.Rand()
+	z.ScalarMult(&m, &k)
+
+	var nonce ristretto.Scalar
+	nonce.Rand()
+
+	var a, b ristretto.Point
+	a.ScalarMult(&g, &nonce)
+	b.ScalarMult(&m, &nonce)
+
+	challenge := deriveChallenge(&g, &y, &m, &z, &a, &b, md)
+
+	var response ristretto.Scalar
+	response.Mul(&challenge, &k)
+	response.Sub(&nonce, &response)
+
+	challengeBytes, err := challenge.MarshalBinary()
+	if err != nil {
+		t.Fatalf("marshal challenge: %s", err)
+	}
+	responseBytes, err := response.MarshalBinary()
+	if err != nil {
+		t.Fatalf("marshal response: %s", err)
+	}
+
+	inputBytes, _ := m.MarshalBinary()
+	outputBytes, _ := z.MarshalBinary()
+	publicKeyBytes, _ := y.MarshalBinary()
+
+	return inputBytes, outputBytes, append(challengeBytes, responseBytes...), publicKeyBytes
+}
+
+func TestVerifyAcceptsValidProof(t *testing.T) {
+	input, output, proof, publicKey := proveTestVector(t, 7)
+
+	ok, err := Verify(input, output, proof, publicKey, 7)
+	if err != nil {
+		t.Fatalf("Verify: %s", err)
+	}
+	if !ok {
+		t.Fatal("Verify rejected a validly constructed proof")
+	}
+}
+
+func TestVerifyRejectsTamperedOutput(t *testing.T) {
+	input, output, proof, publicKey := proveTestVector(t, 7)
+
+	// Flipping raw bytes of a compressed Ristretto point almost always
+	// yields a non-canonical encoding, which Verify would reject with a
+	// decode error rather than exercising the Chaum-Pedersen check this
+	// test is meant to cover. Tamper at the group-element level instead
+	// by adding the base point, which is guaranteed to stay a valid,
+	// different point.
+	var z, g ristretto.Point
+	if err := z.UnmarshalBinary(output); err != nil {
+		t.Fatalf("unmarshal output: %s", err)
+	}
+	g.SetBase()
+	z.Add(&z, &g)
+	tampered, err := z.MarshalBinary()
+	if err != nil {
+		t.Fatalf("marshal tampered output: %s", err)
+	}
+
+	ok, err := Verify(input, tampered, proof, publicKey, 7)
+	if err != nil {
+		t.Fatalf("Verify: %s", err)
+	}
+	if ok {
+		t.Fatal("Verify accepted a proof against a tampered output")
+	}
+}
+
+func TestVerifyRejectsWrongEpoch(t *testing.T) {
+	input, output, proof, publicKey := proveTestVector(t, 7)
+
+	ok, err := Verify(input, output, proof, publicKey, 8)
+	if err != nil {
+		t.Fatalf("Verify: %s", err)
+	}
+	if ok {
+		t.Fatal("Verify accepted a proof under the wrong metadata tag")
+	}
+}
+
+func TestVerifyRejectsShortProof(t *testing.T) {
+	input, output, proof, publicKey := proveTestVector(t, 7)
+
+	if _, err := Verify(input, output, proof[:proofSize-1], publicKey, 7); err == nil {
+		t.Fatal("Verify accepted a truncated proof")
+	}
+}
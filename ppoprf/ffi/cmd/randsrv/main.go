@@ -0,0 +1,224 @@
+// randsrv runs the ppoprf randomness service inside a Nitro Enclave.
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	// This module must be imported first because of its side effects of
+	// seeding our system entropy pool.
+	_ "github.com/brave-experiments/nitro-enclave-utils/randseed"
+
+	nitro "github.com/brave-experiments/nitro-enclave-utils"
+
+	"github.com/brave-experiments/sta-rs/ppoprf/ffi"
+)
+
+var (
+	elog = log.New(os.Stderr, "randsrv: ", log.Ldate|log.Ltime|log.LUTC|log.Lshortfile)
+)
+
+// epochTags lists the metadata tags this deployment rotates through, in
+// order.
+//
+// FIXME make this configurable instead of hard-coded.
+var epochTags = []uint8{0, 1, 2, 3, 4, 5, 6, 7}
+
+// epochDuration is how long each epoch tag stays current before being
+// punctured.
+const epochDuration = 24 * time.Hour
+
+// poolSize is the number of independent randomness server handles kept
+// alive to serve concurrent requests.
+const poolSize = 8
+
+// randomnessRequest is the JSON body POSTed to /randomness: one or
+// more blinded Ristretto points the caller wants evaluated, and the
+// epoch tag to evaluate them under.
+type randomnessRequest struct {
+	Inputs     []string `json:"inputs"`
+	Epoch      uint8    `json:"epoch"`
+	Verifiable bool     `json:"verifiable"`
+}
+
+// randomnessResponse is the JSON body returned by /randomness.
+type randomnessResponse struct {
+	Outputs   []string `json:"outputs"`
+	Proofs    []string `json:"proofs,omitempty"`
+	PublicKey string   `json:"publicKey,omitempty"`
+	Epoch     uint8    `json:"epoch"`
+}
+
+// ristrettoPointSize is the length in bytes of an encoded Ristretto
+// point, used to reject malformed blinded inputs before they reach the
+// FFI boundary.
+const ristrettoPointSize = 32
+
+// decodePoint accepts a blinded Ristretto point encoded as hex or
+// base64.
+func decodePoint(s string) ([]byte, error) {
+	if b, err := hex.DecodeString(s); err == nil {
+		return b, nil
+	}
+	return base64.StdEncoding.DecodeString(s)
+}
+
+// epochAllowed reports whether md is one of tags.
+func epochAllowed(tags []uint8, md uint8) bool {
+	for _, t := range tags {
+		if t == md {
+			return true
+		}
+	}
+	return false
+}
+
+func getRandomnessHandler(pool *ppoprf.ServerPool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req randomnessRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "malformed request body", http.StatusBadRequest)
+			return
+		}
+		if len(req.Inputs) == 0 {
+			http.Error(w, "at least one blinded input is required", http.StatusBadRequest)
+			return
+		}
+		if !epochAllowed(pool.RemainingEpochs(), req.Epoch) {
+			http.Error(w, fmt.Sprintf("epoch %d is not active", req.Epoch), http.StatusBadRequest)
+			return
+		}
+
+		inputs := make([][]byte, len(req.Inputs))
+		for i, s := range req.Inputs {
+			in, err := decodePoint(s)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("input %d: %s", i, err), http.StatusBadRequest)
+				return
+			}
+			if len(in) != ristrettoPointSize {
+				http.Error(w, fmt.Sprintf("input %d is not a %d-byte Ristretto point", i, ristrettoPointSize), http.StatusBadRequest)
+				return
+			}
+			inputs[i] = in
+		}
+
+		var outputs [][32]byte
+		var proofs [][]byte
+		err := pool.WithServer(func(srv *ppoprf.Server) error {
+			var err error
+			outputs, proofs, err = srv.EvalBatch(inputs, req.Epoch, req.Verifiable)
+			return err
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		resp := randomnessResponse{
+			Outputs: make([]string, len(outputs)),
+			Epoch:   req.Epoch,
+		}
+		for i, o := range outputs {
+			resp.Outputs[i] = hex.EncodeToString(o[:])
+		}
+		if req.Verifiable {
+			resp.Proofs = make([]string, len(proofs))
+			for i, p := range proofs {
+				resp.Proofs[i] = hex.EncodeToString(p)
+			}
+
+			var pub []byte
+			if err := pool.WithServer(func(srv *ppoprf.Server) error {
+				var err error
+				pub, err = srv.PublicKey()
+				return err
+			}); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			resp.PublicKey = hex.EncodeToString(pub)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			elog.Printf("Failed to encode randomness response: %s", err)
+		}
+	}
+}
+
+// attestedMetadata is embedded in the user_data field of the
+// attestation document returned by /attestation, binding the enclave's
+// measurement to the PPOPRF state clients should pin.
+type attestedMetadata struct {
+	PublicKey string  `json:"publicKey"`
+	Epochs    []uint8 `json:"epochs"`
+}
+
+func getAttestationHandler(enclave *nitro.Enclave, pool *ppoprf.ServerPool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var pub []byte
+		err := pool.WithServer(func(srv *ppoprf.Server) error {
+			var err error
+			pub, err = srv.PublicKey()
+			return err
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		meta := attestedMetadata{
+			PublicKey: hex.EncodeToString(pub),
+			Epochs:    pool.RemainingEpochs(),
+		}
+
+		userData, err := json.Marshal(meta)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		doc, err := enclave.Attest(nil, userData, nil)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/cbor")
+		w.Write(doc)
+	}
+}
+
+func main() {
+	pool, err := ppoprf.CreateServerPool(poolSize, epochTags)
+	if err != nil {
+		elog.Fatalf("Failed to create randomness server pool: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pool.StartEpochRotation(ctx, epochDuration)
+
+	enclave := nitro.NewEnclave(
+		&nitro.Config{
+			SOCKSProxy: "socks5://127.0.0.1:1080",
+			FQDN:       "nitro.nymity.ch",
+			Port:       8080,
+			Debug:      true,
+			UseACME:    false,
+		},
+	)
+	enclave.AddRoute(http.MethodPost, "/randomness", getRandomnessHandler(pool))
+	enclave.AddRoute(http.MethodGet, "/attestation", getAttestationHandler(enclave, pool))
+	if err := enclave.Start(); err != nil {
+		elog.Fatalf("Enclave terminated: %v", err)
+	}
+}
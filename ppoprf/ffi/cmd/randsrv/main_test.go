@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/bwesterb/go-ristretto"
+
+	"github.com/brave-experiments/sta-rs/ppoprf/ffi"
+)
+
+// TestConcurrentRandomness fires many concurrent /randomness requests
+// against a pooled server, to catch data races over the shared server
+// handles under `go test -race`.
+func TestConcurrentRandomness(t *testing.T) {
+	pool, err := ppoprf.CreateServerPool(4, epochTags)
+	if err != nil {
+		t.Fatalf("CreateServerPool: %s", err)
+	}
+
+	srv := httptest.NewServer(getRandomnessHandler(pool))
+	defer srv.Close()
+
+	const concurrency = 50
+	var wg sync.WaitGroup
+	wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+
+			body, err := json.Marshal(randomnessRequest{
+				Inputs: []string{samplePoint(t)},
+				Epoch:  epochTags[0],
+			})
+			if err != nil {
+				t.Errorf("marshal request: %s", err)
+				return
+			}
+
+			resp, err := http.Post(srv.URL, "application/json", bytes.NewReader(body))
+			if err != nil {
+				t.Errorf("POST /randomness: %s", err)
+				return
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("unexpected status: %s", resp.Status)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func samplePoint(t *testing.T) string {
+	t.Helper()
+	var c ristretto.Point
+	c.Rand()
+	b, err := c.MarshalBinary()
+	if err != nil {
+		t.Fatalf("marshal point: %s", err)
+	}
+	return hex.EncodeToString(b)
+}
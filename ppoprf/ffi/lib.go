@@ -10,8 +10,13 @@ package ppoprf
 import "C"
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"runtime"
+	"sync"
+	"time"
+	"unsafe"
 )
 
 // Embed an zero-length struct to mark our wrapped structs `noCopy`
@@ -31,10 +36,20 @@ type noCopy struct{}
 func (*noCopy) Lock()   {}
 func (*noCopy) Unlock() {}
 
-// ppoprf randomness server instance
+// Server is a ppoprf randomness server instance. It owns a rotating set
+// of epoch metadata tags: each tag but the last is punctured
+// (irrevocably disabled) once its epoch ends, and the last tag stays
+// current once rotation reaches it. Eval does not restrict md to the
+// current tag; any tag not yet punctured can be evaluated.
 type Server struct {
 	raw    *C.RandomnessServer
 	noCopy noCopy
+
+	mu       sync.Mutex
+	tags     []uint8
+	current  int
+	duration time.Duration
+	cancel   context.CancelFunc
 }
 
 func serverFinalizer(server *Server) {
@@ -42,17 +57,326 @@ func serverFinalizer(server *Server) {
 	server.raw = nil
 }
 
-// Create a new ppoprf randomness server instance.
+// CreateServer creates a new ppoprf randomness server instance.
 //
-// FIXME Pass in a list of 8-bit tags defining epochs.
-// The instance will generate its own secret key.
-func CreateServer() (*Server, error) {
-	// FIXME should we runtime.LockOSThread() here?
+// epochTags lists the 8-bit metadata tags the server will serve, in
+// rotation order. epochDuration is how long each epoch stays current
+// before StartEpochRotation punctures it and advances to the next tag.
+// The instance generates its own secret key.
+func CreateServer(epochTags []uint8, epochDuration time.Duration) (*Server, error) {
+	if len(epochTags) == 0 {
+		return nil, errors.New("CreateServer: at least one epoch tag is required")
+	}
+
 	raw := C.randomness_server_create()
 	if raw == nil {
 		return nil, errors.New("Failed to create randomness server")
 	}
-	server := &Server{raw: raw}
+	server := &Server{
+		raw:      raw,
+		tags:     append([]uint8(nil), epochTags...),
+		duration: epochDuration,
+	}
 	runtime.SetFinalizer(server, serverFinalizer)
 	return server, nil
 }
+
+// proofSize is the length in bytes of the DLEQ proof attached to a
+// verifiable evaluation: a Chaum-Pedersen (challenge, response) scalar
+// pair.
+const proofSize = 64
+
+// publicKeySize is the length in bytes of the Ristretto point the
+// server commits to as its public key.
+const publicKeySize = 32
+
+// Eval evaluates the PPOPRF on input under metadata tag md, returning
+// the 32-byte output. If verifiable is true, the returned proof is a
+// DLEQ proof that output was computed under the server's committed
+// secret key; callers can check it with client.Verify against the
+// value from PublicKey.
+func (s *Server) Eval(input []byte, md uint8, verifiable bool) (output [32]byte, proof []byte, err error) {
+	if len(input) == 0 {
+		return output, nil, errors.New("Eval: empty input")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var proofBuf [proofSize]byte
+	var proofPtr *C.uint8_t
+	if verifiable {
+		proofPtr = (*C.uint8_t)(unsafe.Pointer(&proofBuf[0]))
+	}
+
+	if !bool(C.randomness_server_eval(s.raw,
+		(*C.uint8_t)(unsafe.Pointer(&input[0])),
+		(C.ulong)(md),
+		(C.bool)(verifiable),
+		(*C.uint8_t)(unsafe.Pointer(&output[0])),
+		proofPtr)) {
+		return output, nil, fmt.Errorf("Eval: evaluation failed for epoch %d", md)
+	}
+	if verifiable {
+		proof = proofBuf[:]
+	}
+	return output, proof, nil
+}
+
+// EvalBatch evaluates the PPOPRF on multiple inputs under a single
+// metadata tag in one FFI call, amortizing the per-call CGo overhead
+// that calling Eval once per input would otherwise pay.
+func (s *Server) EvalBatch(inputs [][]byte, md uint8, verifiable bool) (outputs [][32]byte, proofs [][]byte, err error) {
+	if len(inputs) == 0 {
+		return nil, nil, errors.New("EvalBatch: no inputs")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	flat := make([]byte, 0, len(inputs)*publicKeySize)
+	for i, in := range inputs {
+		if len(in) != publicKeySize {
+			return nil, nil, fmt.Errorf("EvalBatch: input %d is not a %d-byte Ristretto point", i, publicKeySize)
+		}
+		flat = append(flat, in...)
+	}
+
+	outBuf := make([]byte, len(inputs)*publicKeySize)
+	var proofBuf []byte
+	var proofPtr *C.uint8_t
+	if verifiable {
+		proofBuf = make([]byte, len(inputs)*proofSize)
+		proofPtr = (*C.uint8_t)(unsafe.Pointer(&proofBuf[0]))
+	}
+
+	if !bool(C.randomness_server_eval_batch(s.raw,
+		(*C.uint8_t)(unsafe.Pointer(&flat[0])),
+		(C.size_t)(len(inputs)),
+		(C.ulong)(md),
+		(C.bool)(verifiable),
+		(*C.uint8_t)(unsafe.Pointer(&outBuf[0])),
+		proofPtr)) {
+		return nil, nil, fmt.Errorf("EvalBatch: evaluation failed for epoch %d", md)
+	}
+
+	outputs = make([][32]byte, len(inputs))
+	for i := range outputs {
+		copy(outputs[i][:], outBuf[i*publicKeySize:(i+1)*publicKeySize])
+	}
+	if verifiable {
+		proofs = make([][]byte, len(inputs))
+		for i := range proofs {
+			proofs[i] = proofBuf[i*proofSize : (i+1)*proofSize]
+		}
+	}
+	return outputs, proofs, nil
+}
+
+// PublicKey returns the server's public commitment to its current
+// secret key, used by clients to check DLEQ proofs returned by Eval.
+func (s *Server) PublicKey() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var buf [publicKeySize]byte
+	if !bool(C.randomness_server_public_key(s.raw, (*C.uint8_t)(unsafe.Pointer(&buf[0])))) {
+		return nil, errors.New("PublicKey: failed to fetch server public key")
+	}
+	return buf[:], nil
+}
+
+// Puncture removes the key material for epoch tag md, irrevocably
+// preventing any further evaluations under it.
+func (s *Server) Puncture(md uint8) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !bool(C.randomness_server_puncture(s.raw, C.uint8_t(md))) {
+		return fmt.Errorf("Puncture: failed to puncture epoch %d", md)
+	}
+	return nil
+}
+
+// CurrentEpoch returns the metadata tag for the epoch presently being
+// served.
+func (s *Server) CurrentEpoch() uint8 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tags[s.current]
+}
+
+// RemainingEpochs returns the metadata tags, including the current one,
+// that have not yet been punctured.
+func (s *Server) RemainingEpochs() []uint8 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]uint8(nil), s.tags[s.current:]...)
+}
+
+// StartEpochRotation launches a background goroutine that punctures the
+// current epoch and advances to the next tag every epochDuration, until
+// ctx is cancelled or the last tag is current. The last tag is never
+// punctured. It is safe to call at most once per Server.
+func (s *Server) StartEpochRotation(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	s.mu.Lock()
+	s.cancel = cancel
+	s.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(s.duration)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.mu.Lock()
+				expired := s.tags[s.current]
+				if s.current+1 >= len(s.tags) {
+					s.mu.Unlock()
+					return
+				}
+				s.current++
+				s.mu.Unlock()
+
+				if err := s.Puncture(expired); err != nil {
+					// The epoch has already advanced regardless, so
+					// rotation keeps going even if the puncture call
+					// itself failed.
+					continue
+				}
+			}
+		}
+	}()
+}
+
+// ServerPool is a fixed-size set of Server handles that share the same
+// secret key. Concurrent callers each borrow their own handle instead
+// of serializing through a single Server's mutex, trading memory for
+// throughput.
+//
+// All handles in the pool serve the same epoch schedule: the pool
+// itself is the sole authority on which tag is current and which have
+// been punctured, so CurrentEpoch, RemainingEpochs and epoch rotation
+// are pool-level operations rather than per-handle ones. Calling
+// Puncture, CurrentEpoch or RemainingEpochs directly on a Server drawn
+// from a pool reflects only that handle's own, otherwise-unused state
+// and should be avoided; use the ServerPool methods instead.
+type ServerPool struct {
+	handles []*Server
+	free    chan *Server
+
+	mu      sync.Mutex
+	tags    []uint8
+	current int
+}
+
+// CreateServerPool creates size independent randomness server handles
+// serving epochTags, all sharing the secret key of the first handle
+// via randomness_server_clone.
+func CreateServerPool(size int, epochTags []uint8) (*ServerPool, error) {
+	if size <= 0 {
+		return nil, errors.New("CreateServerPool: size must be positive")
+	}
+	if len(epochTags) == 0 {
+		return nil, errors.New("CreateServerPool: at least one epoch tag is required")
+	}
+
+	first, err := CreateServer(epochTags, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := &ServerPool{
+		handles: make([]*Server, 0, size),
+		free:    make(chan *Server, size),
+		tags:    append([]uint8(nil), epochTags...),
+	}
+	pool.handles = append(pool.handles, first)
+	pool.free <- first
+	for i := 1; i < size; i++ {
+		raw := C.randomness_server_clone(first.raw)
+		if raw == nil {
+			return nil, fmt.Errorf("CreateServerPool: failed to clone handle %d", i)
+		}
+		clone := &Server{raw: raw, tags: append([]uint8(nil), epochTags...)}
+		runtime.SetFinalizer(clone, serverFinalizer)
+		pool.handles = append(pool.handles, clone)
+		pool.free <- clone
+	}
+	return pool, nil
+}
+
+// Get removes a Server from the pool, blocking until one is available.
+func (p *ServerPool) Get() *Server {
+	return <-p.free
+}
+
+// Put returns a Server to the pool.
+func (p *ServerPool) Put(s *Server) {
+	p.free <- s
+}
+
+// WithServer borrows a Server for the duration of fn and returns it to
+// the pool afterwards, even if fn returns an error.
+func (p *ServerPool) WithServer(fn func(*Server) error) error {
+	s := p.Get()
+	defer p.Put(s)
+	return fn(s)
+}
+
+// CurrentEpoch returns the metadata tag presently current across every
+// handle in the pool.
+func (p *ServerPool) CurrentEpoch() uint8 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.tags[p.current]
+}
+
+// RemainingEpochs returns the metadata tags, including the current one,
+// that have not yet been punctured on any handle in the pool.
+func (p *ServerPool) RemainingEpochs() []uint8 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]uint8(nil), p.tags[p.current:]...)
+}
+
+// StartEpochRotation launches a single background goroutine that
+// punctures the current epoch on every handle in the pool and advances
+// to the next tag every epochDuration, until ctx is cancelled or the
+// last tag is current. The last tag is never punctured. The pool
+// advances to the next tag before puncturing the expired one on each
+// handle, so RemainingEpochs stops listing a tag before any handle has
+// punctured it.
+func (p *ServerPool) StartEpochRotation(ctx context.Context, epochDuration time.Duration) {
+	go func() {
+		ticker := time.NewTicker(epochDuration)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.mu.Lock()
+				expired := p.tags[p.current]
+				if p.current+1 >= len(p.tags) {
+					p.mu.Unlock()
+					return
+				}
+				p.current++
+				p.mu.Unlock()
+
+				for _, s := range p.handles {
+					if err := s.Puncture(expired); err != nil {
+						// The pool's epoch state has already advanced
+						// regardless, so rotation keeps going even if
+						// puncturing this particular handle failed.
+						continue
+					}
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,59 @@
+package ppoprf
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bwesterb/go-ristretto"
+)
+
+const benchEpoch = 0
+
+func benchServer(b *testing.B) *Server {
+	srv, err := CreateServer([]uint8{benchEpoch}, time.Hour)
+	if err != nil {
+		b.Fatalf("CreateServer: %s", err)
+	}
+	return srv
+}
+
+func benchInputs(n int) [][]byte {
+	inputs := make([][]byte, n)
+	for i := range inputs {
+		var c ristretto.Point
+		c.Rand()
+		b, _ := c.MarshalBinary()
+		inputs[i] = b
+	}
+	return inputs
+}
+
+// BenchmarkEvalSequential measures calling Eval once per input, paying
+// the CGo call overhead for every evaluation.
+func BenchmarkEvalSequential(b *testing.B) {
+	srv := benchServer(b)
+	inputs := benchInputs(64)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, in := range inputs {
+			if _, _, err := srv.Eval(in, benchEpoch, false); err != nil {
+				b.Fatalf("Eval: %s", err)
+			}
+		}
+	}
+}
+
+// BenchmarkEvalBatch measures the same set of evaluations pushed down
+// into a single EvalBatch call.
+func BenchmarkEvalBatch(b *testing.B) {
+	srv := benchServer(b)
+	inputs := benchInputs(64)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := srv.EvalBatch(inputs, benchEpoch, false); err != nil {
+			b.Fatalf("EvalBatch: %s", err)
+		}
+	}
+}